@@ -0,0 +1,26 @@
+// Package env provides the deep-merge semantics used to layer per-environment
+// values on top of a shared base, as used by files.Dir.Render.
+package env
+
+// Merge performs a mergo-style deep merge of src into dst: maps are merged
+// key by key recursively, while any other value in src - including slices -
+// overwrites the value at that key in dst. dst is mutated and returned, so
+// pass a fresh map (e.g. map[string]interface{}{}) if the original must stay
+// untouched.
+func Merge(dst, src map[string]interface{}) map[string]interface{} {
+	if dst == nil {
+		dst = map[string]interface{}{}
+	}
+
+	for k, v := range src {
+		if sv, ok := v.(map[string]interface{}); ok {
+			if dv, ok := dst[k].(map[string]interface{}); ok {
+				dst[k] = Merge(dv, sv)
+				continue
+			}
+		}
+		dst[k] = v
+	}
+
+	return dst
+}
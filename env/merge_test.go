@@ -0,0 +1,81 @@
+package env
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergePrecedence(t *testing.T) {
+	dst := map[string]interface{}{
+		"name":  "base",
+		"shared": "keep-from-dst",
+	}
+	src := map[string]interface{}{
+		"name": "override",
+	}
+
+	got := Merge(dst, src)
+	want := map[string]interface{}{
+		"name":   "override",
+		"shared": "keep-from-dst",
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Merge() = %#v, want %#v", got, want)
+	}
+}
+
+func TestMergeNestedMaps(t *testing.T) {
+	dst := map[string]interface{}{
+		"database": map[string]interface{}{
+			"host": "localhost",
+			"port": 5432,
+		},
+	}
+	src := map[string]interface{}{
+		"database": map[string]interface{}{
+			"host": "prod-db.internal",
+		},
+	}
+
+	got := Merge(dst, src)
+	want := map[string]interface{}{
+		"database": map[string]interface{}{
+			"host": "prod-db.internal",
+			"port": 5432,
+		},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Merge() = %#v, want %#v", got, want)
+	}
+}
+
+func TestMergeSlicesAreReplacedNotAppended(t *testing.T) {
+	dst := map[string]interface{}{
+		"tags": []interface{}{"a", "b"},
+	}
+	src := map[string]interface{}{
+		"tags": []interface{}{"c"},
+	}
+
+	got := Merge(dst, src)
+	want := map[string]interface{}{
+		"tags": []interface{}{"c"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Merge() = %#v, want %#v", got, want)
+	}
+}
+
+func TestMergeNilDst(t *testing.T) {
+	src := map[string]interface{}{"name": "override"}
+
+	got := Merge(nil, src)
+	want := map[string]interface{}{"name": "override"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Merge() = %#v, want %#v", got, want)
+	}
+}
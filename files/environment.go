@@ -0,0 +1,124 @@
+package files
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/x0rzkov/tool-tmplt/env"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+// Environment describes the per-environment values layered on top of a
+// shared template pack rendered by Dir.Render.
+type Environment struct {
+	// Name selects the values.<Name>.yaml overlay, e.g. "staging" or "prod".
+	Name string
+
+	// Values holds the deep-merged result of values.yaml, values.<Name>.yaml,
+	// and any explicit overrides passed to Render. It is set by every call to
+	// Render before the template is read, so a call that then fails to read,
+	// parse or execute the template still leaves it set.
+	Values map[string]interface{}
+
+	// Dir is this environment's own files dir, derived from the Dir passed
+	// to Render as Dir/Name, for environment-specific static assets that
+	// live alongside the shared template pack.
+	Dir Dir
+}
+
+// NewEnvironment returns an Environment for the given name. Its Values and
+// Dir are populated the first time it is passed to Dir.Render.
+func NewEnvironment(name string) *Environment {
+	return &Environment{Name: name}
+}
+
+// Render loads name as a Go text/template from dir, executes it with
+// .Environment and .Values in scope alongside the template funcs from
+// renderFuncMap (ToYaml/FromYaml/ToJson/FromJson/ToToml, their array/
+// multi-document/cross-format counterparts, and the path helpers from
+// FuncMap), and returns the rendered string.
+//
+// This is a method on Dir rather than Files: a Files value holds no path back
+// to the Dir it came from, and loading the layered values files (below) needs
+// that Dir. Dir.Get/Dir.Glob are the existing
+// precedent for filesystem-touching operations living on Dir rather than
+// Files, so Render follows it.
+//
+// Values are layered with increasing precedence: a base values.yaml in dir,
+// overlaid by values.<e.Name>.yaml in dir if present, overlaid by overrides.
+// Either values file may be absent; a missing file contributes nothing
+// rather than being an error. e is mutated in place with the merged Values
+// and its derived Dir so that templates rendered later against the same
+// Environment see a consistent view.
+func (dir Dir) Render(name string, e *Environment, overrides map[string]interface{}) (string, error) {
+	merged := env.Merge(loadValues(dir, "values.yaml"), loadValues(dir, fmt.Sprintf("values.%s.yaml", e.Name)))
+	merged = env.Merge(merged, overrides)
+
+	e.Values = merged
+	e.Dir = Dir(filepath.Join(string(dir), e.Name))
+
+	p := filepath.Join(string(dir), name)
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		return "", fmt.Errorf("files.Render %v failed: %v", name, err)
+	}
+
+	t, err := template.New(name).Funcs(renderFuncMap()).Parse(string(b))
+	if err != nil {
+		return "", fmt.Errorf("files.Render %v failed: %v", name, err)
+	}
+
+	data := struct {
+		Environment *Environment
+		Values      map[string]interface{}
+	}{
+		Environment: e,
+		Values:      merged,
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if err := t.Execute(buf, data); err != nil {
+		return "", fmt.Errorf("files.Render %v failed: %v", name, err)
+	}
+	return buf.String(), nil
+}
+
+// renderFuncMap returns every function in this package documented as
+// "designed to be called from a template", merging the conversion helpers
+// with the path helpers from FuncMap, for use by Render.
+func renderFuncMap() template.FuncMap {
+	funcs := template.FuncMap{
+		"toYaml":            ToYaml,
+		"fromYaml":          FromYaml,
+		"fromYamlArray":     FromYamlArray,
+		"fromYamlDocuments": FromYamlDocuments,
+		"toJson":            ToJson,
+		"fromJson":          FromJson,
+		"fromJsonArray":     FromJsonArray,
+		"toToml":            ToToml,
+		"toTomlArray":       ToTomlArray,
+		"yamlToJson":        YAMLToJson,
+		"jsonToYaml":        JSONToYaml,
+	}
+	for name, fn := range FuncMap() {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+// loadValues reads name from dir and parses it as YAML, returning an empty
+// map if the file does not exist so that optional overlays are a no-op.
+func loadValues(dir Dir, name string) map[string]interface{} {
+	p := filepath.Join(string(dir), name)
+	if _, err := os.Stat(p); err != nil {
+		return map[string]interface{}{}
+	}
+
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		return map[string]interface{}{}
+	}
+	return FromYaml(string(b))
+}
@@ -0,0 +1,85 @@
+package files
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatalf("writeFile %v: %v", name, err)
+	}
+}
+
+func TestRenderLayersValuesByPrecedence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tool-tmplt-render")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeFile(t, dir, "values.yaml", "name: base\nregion: us-east-1\n")
+	writeFile(t, dir, "values.prod.yaml", "name: prod\n")
+	writeFile(t, dir, "app.tmpl", "{{.Values.name}}/{{.Values.region}}/{{.Environment.Name}}")
+
+	e := NewEnvironment("prod")
+	out, err := Dir(dir).Render("app.tmpl", e, map[string]interface{}{"region": "eu-west-1"})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	want := "prod/eu-west-1/prod"
+	if out != want {
+		t.Fatalf("Render() = %q, want %q", out, want)
+	}
+
+	wantDir := Dir(filepath.Join(dir, "prod"))
+	if e.Dir != wantDir {
+		t.Fatalf("Environment.Dir = %q, want %q", e.Dir, wantDir)
+	}
+}
+
+func TestRenderExposesConversionAndPathFuncs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tool-tmplt-render")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeFile(t, dir, "values.yaml", "name: base\n")
+	writeFile(t, dir, "app.tmpl",
+		"{{ base \"a/b.yaml\" }}/{{ (fromYamlArray \"- x\\n- y\\n\") | len }}/{{ yamlToJson \"a: 1\" }}")
+
+	out, err := Dir(dir).Render("app.tmpl", NewEnvironment("dev"), nil)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	want := "b.yaml/2/{\"a\":1}"
+	if out != want {
+		t.Fatalf("Render() = %q, want %q", out, want)
+	}
+}
+
+func TestRenderWithoutOverlayUsesBaseValues(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tool-tmplt-render")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeFile(t, dir, "values.yaml", "name: base\n")
+	writeFile(t, dir, "app.tmpl", "{{.Values.name}}")
+
+	out, err := Dir(dir).Render("app.tmpl", NewEnvironment("dev"), nil)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	if out != "base" {
+		t.Fatalf("Render() = %q, want %q", out, "base")
+	}
+}
@@ -24,59 +24,277 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/json"
-	"github.com/golang/glog"
+	"fmt"
+	"github.com/gobwas/glob"
 	"gopkg.in/yaml.v2"
 	"io/ioutil"
+	"log"
+	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"github.com/BurntSushi/toml"
 )
 
 // Dir is the path to load relative files from.
 type Dir string
 
+// dirErrors holds the errors recorded by Get/Glob, keyed by Dir, so that
+// callers embedding this package as a library can surface them instead of
+// the process being killed outright. Guarded by dirErrorsMu since a Dir may
+// be used concurrently from multiple goroutines.
+//
+// A long-running process may render against many distinct or ephemeral Dir
+// paths (temp dirs, per-tenant paths, per-request checkouts) and never call
+// ClearErrors, so this is bounded on two axes rather than left to grow for
+// the life of the process: dirErrorsOrder caps the number of distinct Dirs
+// tracked at once (maxTrackedDirs), evicting the least-recently-touched one,
+// and each Dir's own slice is capped at maxErrorsPerDir, dropping its oldest
+// entries first.
+const (
+	maxTrackedDirs  = 256
+	maxErrorsPerDir = 64
+)
+
+var (
+	dirErrorsMu    sync.Mutex
+	dirErrors      = map[Dir][]error{}
+	dirErrorsOrder []Dir // oldest-touched first; re-appended on every touch
+)
+
+func (dir Dir) recordError(err error) {
+	dirErrorsMu.Lock()
+	defer dirErrorsMu.Unlock()
+
+	if _, tracked := dirErrors[dir]; !tracked && len(dirErrorsOrder) >= maxTrackedDirs {
+		evict := dirErrorsOrder[0]
+		dirErrorsOrder = dirErrorsOrder[1:]
+		delete(dirErrors, evict)
+	}
+
+	errs := append(dirErrors[dir], err)
+	if len(errs) > maxErrorsPerDir {
+		errs = errs[len(errs)-maxErrorsPerDir:]
+	}
+	dirErrors[dir] = errs
+	dirErrorsOrder = touchDirOrder(dirErrorsOrder, dir)
+}
+
+// touchDirOrder moves dir to the end of order (appending it if absent) so
+// that dirErrorsOrder always reflects least-recently-touched-first.
+func touchDirOrder(order []Dir, dir Dir) []Dir {
+	for i, d := range order {
+		if d == dir {
+			return append(append(order[:i], order[i+1:]...), dir)
+		}
+	}
+	return append(order, dir)
+}
+
+// Errors returns the errors recorded for this Dir by Get/GetE/Glob/GlobE, in
+// the order they occurred, capped at the most recent maxErrorsPerDir.
+func (dir Dir) Errors() []error {
+	dirErrorsMu.Lock()
+	defer dirErrorsMu.Unlock()
+	out := make([]error, len(dirErrors[dir]))
+	copy(out, dirErrors[dir])
+	return out
+}
+
+// ClearErrors discards the errors recorded for this Dir, so that a
+// long-running process can scope Errors to the calls made since the last
+// ClearErrors rather than accumulating them for the lifetime of the
+// process.
+func (dir Dir) ClearErrors() {
+	dirErrorsMu.Lock()
+	defer dirErrorsMu.Unlock()
+	delete(dirErrors, dir)
+	for i, d := range dirErrorsOrder {
+		if d == dir {
+			dirErrorsOrder = append(dirErrorsOrder[:i], dirErrorsOrder[i+1:]...)
+			break
+		}
+	}
+}
+
 // Files is a map of files in a chart that can be accessed from a template.
-type Files map[string]string
+// Values are held as raw bytes so that binary assets (TLS certs, keystores,
+// images) survive a round trip through AsConfig/AsSecrets without UTF-8
+// corruption; use Get for a string view of a named entry.
+type Files map[string][]byte
+
+// NewFiles builds a Files value from a map[string]string, for callers
+// migrating from the old string-backed representation.
+func NewFiles(m map[string]string) Files {
+	f := make(Files, len(m))
+	for k, v := range m {
+		f[k] = []byte(v)
+	}
+	return f
+}
+
+// Get returns a string representation of the named entry, or "" if it is
+// not present. This is kept distinct from Dir.Get so that templates can
+// call .Files.Get after a .Files.Glob without touching the filesystem
+// again.
+//
+// This is designed to be called from a template.
+//
+//	{{ (.Files.Glob "foo/**").Get "foo/bar.txt" }}
+func (f Files) Get(name string) string {
+	if f == nil {
+		return ""
+	}
+	return string(f[name])
+}
+
+// GetBytes returns the raw bytes of the named entry, or nil if it is not
+// present. Use this instead of Get when the content may not be valid
+// UTF-8, such as a TLS certificate or keystore destined for AsSecrets.
+func (f Files) GetBytes(name string) []byte {
+	if f == nil {
+		return nil
+	}
+	return f[name]
+}
+
+// Lines returns each line of a named file (split by "\n") as a slice, so it
+// can be ranged over in your templates.
+//
+// This is designed to be called from a template.
+//
+// {{ range .Files.Lines "foo/bar.html" }}
+// {{ . }}{{ end }}
+func (f Files) Lines(name string) []string {
+	if f == nil || f[name] == nil {
+		return []string{}
+	}
 
-//TODO type Files map[string][]byte
+	return strings.Split(string(f[name]), "\n")
+}
 
 // Get returns a string representation of the given file.
 //
 // Fetch the contents of a file as a string. It is designed to be called in a
-// template.
+// template. On failure it logs the error, records it for Errors, and returns
+// "" rather than killing the process; library callers that want the error
+// itself should use GetE.
 //
 //	{{.Files.Get "foo"}}
 func (dir Dir) Get(name string) string {
+	s, err := dir.GetE(name)
+	if err != nil {
+		log.Printf("files: %v", err)
+		return ""
+	}
+	return s
+}
+
+// GetE is the error-returning form of Get.
+func (dir Dir) GetE(name string) (string, error) {
 	p := filepath.Join(string(dir), name)
 	b, err := ioutil.ReadFile(p)
 	if err != nil {
-		glog.Exitf("Files.Get failed: %v", err)
+		err = fmt.Errorf("files.Get %v failed: %v", name, err)
+		dir.recordError(err)
+		return "", err
 	}
-	return string(b)
+	return string(b), nil
 }
 
 // Glob takes a glob pattern and returns another files object only containing
 // matched  files.
 //
-// This is designed to be called from a template.
+// This is designed to be called from a template. On failure it logs the
+// error, records it for Errors, and returns an empty Files rather than
+// killing the process; library callers that want the error itself should
+// use GlobE.
 //
 // {{ range $name, $content := .Files.Glob("foo/**") }}
 // {{ $name }}: |
 // {{ .Files.Get($name) | indent 4 }}{{ end }}
 func (dir Dir) Glob(pattern string) Files {
+	f, err := dir.GlobE(pattern)
+	if err != nil {
+		log.Printf("files: %v", err)
+		return make(Files, 0)
+	}
+	return f
+}
+
+// GlobE is the error-returning form of Glob.
+func (dir Dir) GlobE(pattern string) (Files, error) {
 	p := filepath.Join(string(dir), pattern)
 	fs, err := filepath.Glob(p)
 	if err != nil {
-		glog.Exitf("Files.Glob %v failed: %v", pattern, err)
+		err = fmt.Errorf("files.Glob %v failed: %v", pattern, err)
+		dir.recordError(err)
+		return nil, err
 	}
 
-	//TODO m := map[string][]byte{}
 	m := make(Files, 0)
 	for _, f := range fs {
+		fi, err := os.Stat(f)
+		if err != nil {
+			err = fmt.Errorf("files.Glob %v failed: %v", pattern, err)
+			dir.recordError(err)
+			continue
+		}
+		if fi.IsDir() {
+			continue
+		}
+
 		b, err := ioutil.ReadFile(f)
 		if err != nil {
-			glog.Exitf("Files.Glob %v failed: %v", pattern, err)
+			err = fmt.Errorf("files.Glob %v failed: %v", pattern, err)
+			dir.recordError(err)
+			continue
+		}
+		rel, err := filepath.Rel(string(dir), f)
+		if err != nil {
+			rel = f
+		}
+		m[filepath.ToSlash(rel)] = b
+	}
+	return m, nil
+}
+
+// Glob takes a glob pattern and returns another Files object containing only
+// the entries whose keys match, or whose base names match, using an in-memory
+// matcher rather than touching the filesystem again.
+//
+// This is designed to be called from a template, so that the result of
+// Dir.Glob can be narrowed further without re-reading the Dir:
+//
+// {{ range $name, $content := (.Files.Glob "config/**").Glob "*.yaml" }}
+// {{ $name }}: |
+// {{ $content | indent 4 }}{{ end }}
+func (f Files) Glob(pattern string) Files {
+	m := make(Files, 0)
+	if f == nil {
+		return m
+	}
+
+	g, err := glob.Compile(pattern, '/')
+	if err != nil {
+		log.Printf("files: Files.Glob %v failed: %v", pattern, err)
+		return m
+	}
+	// A plain pattern like "*.yaml" is meant to match against the file name
+	// alone, not the whole key, since callers chain this off an already
+	// path-scoped Dir.Glob (e.g. (.Files.Glob "config/**").Glob "*.yaml").
+	// Compiling it again without a separator and matching it against the base
+	// name of each key lets such a pattern select files at any depth.
+	gBase, err := glob.Compile(pattern)
+	if err != nil {
+		log.Printf("files: Files.Glob %v failed: %v", pattern, err)
+		return m
+	}
+
+	for k, v := range f {
+		if g.Match(k) || gBase.Match(filepath.Base(k)) {
+			m[k] = v
 		}
-		m[f] = string(b)
 	}
 	return m
 }
@@ -132,27 +350,12 @@ func (f Files) AsSecrets() string {
 	m := map[string]string{}
 
 	for k, v := range f {
-		m[filepath.Base(k)] = string(base64.StdEncoding.EncodeToString([]byte(v)))
+		m[filepath.Base(k)] = base64.StdEncoding.EncodeToString(v)
 	}
 
 	return ToYaml(m)
 }
 
-// Lines returns each line of a named file (split by "\n") as a slice, so it can
-// be ranged over in your templates.
-//
-// This is designed to be called from a template.
-//
-// {{ range .Files.Lines "foo/bar.html" }}
-// {{ . }}{{ end }}
-//func (f Files) Lines(path string) []string {
-//	if f == nil || f[path] == nil {
-//		return []string{}
-//	}
-//
-//	return strings.Split(string(f[path]), "\n")
-//}
-
 // ToYaml takes an interface, marshals it to yaml, and returns a string. It will
 // always return a string, even on marshal error (empty string).
 //
@@ -172,14 +375,141 @@ func ToYaml(v interface{}) string {
 // This is not a general-purpose YAML parser, and will not parse all valid
 // YAML documents. Additionally, because its intended use is within templates
 // it tolerates errors. It will insert the returned error message string into
-// m["Error"] in the returned map.
+// m["Error"] in the returned map. Nested maps are recursively normalized to
+// map[string]interface{} (gopkg.in/yaml.v2 decodes them as
+// map[interface{}]interface{}, which encoding/json then refuses to marshal),
+// so the result is ready to pass straight into ToJson.
 func FromYaml(str string) map[string]interface{} {
 	m := map[string]interface{}{}
 
 	if err := yaml.Unmarshal([]byte(str), &m); err != nil {
-		m["Error"] = err.Error()
+		return map[string]interface{}{"Error": err.Error()}
 	}
-	return m
+
+	norm, err := normalizeYamlKeys(m)
+	if err != nil {
+		return map[string]interface{}{"Error": err.Error()}
+	}
+	return norm.(map[string]interface{})
+}
+
+// FromYamlArray converts a YAML document whose root is a sequence into a
+// []interface{}.
+//
+// This is not a general-purpose YAML parser, and will not parse all valid
+// YAML documents. Additionally, because its intended use is within templates
+// it tolerates errors. On error it returns a single-element slice whose
+// element is a map with the error message string in m["Error"], mirroring
+// how FromYaml reports failures. As with FromYaml, nested maps are
+// recursively normalized to map[string]interface{}.
+func FromYamlArray(str string) []interface{} {
+	a := []interface{}{}
+
+	if err := yaml.Unmarshal([]byte(str), &a); err != nil {
+		return []interface{}{map[string]interface{}{"Error": err.Error()}}
+	}
+
+	norm, err := normalizeYamlKeys(a)
+	if err != nil {
+		return []interface{}{map[string]interface{}{"Error": err.Error()}}
+	}
+	return norm.([]interface{})
+}
+
+// normalizeYamlKeys walks a value decoded by gopkg.in/yaml.v2, converting
+// every map[interface{}]interface{} (and its nested values) into
+// map[string]interface{}, erroring if a key cannot be represented as a
+// string. Values of other kinds, including map[string]interface{} and
+// []interface{}, are walked but otherwise returned unchanged.
+func normalizeYamlKeys(v interface{}) (interface{}, error) {
+	switch vv := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(vv))
+		for k, val := range vv {
+			ks, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("cannot convert non-string YAML key %v (%T) to string", k, k)
+			}
+			nv, err := normalizeYamlKeys(val)
+			if err != nil {
+				return nil, err
+			}
+			m[ks] = nv
+		}
+		return m, nil
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(vv))
+		for k, val := range vv {
+			nv, err := normalizeYamlKeys(val)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = nv
+		}
+		return m, nil
+	case []interface{}:
+		a := make([]interface{}, len(vv))
+		for i, val := range vv {
+			nv, err := normalizeYamlKeys(val)
+			if err != nil {
+				return nil, err
+			}
+			a[i] = nv
+		}
+		return a, nil
+	default:
+		return v, nil
+	}
+}
+
+// YAMLToJson converts a YAML document to its JSON representation, walking
+// the decoded tree to normalize map[interface{}]interface{} keys to strings
+// first so that encoding/json can marshal it without the caller having to do
+// a manual conversion pass. It always returns a string: the error message in
+// its place if the input fails to parse or holds a non-string key, or "" if
+// marshalling to JSON fails, as ToJson does.
+func YAMLToJson(str string) string {
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(str), &v); err != nil {
+		return err.Error()
+	}
+
+	norm, err := normalizeYamlKeys(v)
+	if err != nil {
+		return err.Error()
+	}
+	return ToJson(norm)
+}
+
+// JSONToYaml converts a JSON document to its YAML representation. It always
+// returns a string: the error message in its place if the input fails to
+// parse, or "" if marshalling to YAML fails, as ToYaml does.
+func JSONToYaml(str string) string {
+	var v interface{}
+	if err := json.Unmarshal([]byte(str), &v); err != nil {
+		return err.Error()
+	}
+	return ToYaml(v)
+}
+
+// FromYamlDocuments splits a multi-document YAML stream on "---" and parses
+// each document independently into a map[string]interface{}.
+//
+// This is not a general-purpose YAML parser, and will not parse all valid
+// YAML documents. Additionally, because its intended use is within templates
+// it tolerates errors: a document that fails to parse is reported as a
+// single map with the error message string in m["Error"], in place in the
+// returned slice, so that one bad document doesn't lose the rest.
+func FromYamlDocuments(str string) []map[string]interface{} {
+	docs := []map[string]interface{}{}
+
+	for _, part := range strings.Split(str, "\n---") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		docs = append(docs, FromYaml(part))
+	}
+	return docs
 }
 
 // ToToml takes an interface, marshals it to toml, and returns a string. It will
@@ -196,6 +526,21 @@ func ToToml(v interface{}) string {
 	return b.String()
 }
 
+// ToTomlArray takes a slice and marshals each element to its own TOML
+// document, joined back to back. TOML documents must be tables at the root,
+// so unlike ToYaml/ToJson there is no single "array at the root" encoding;
+// this mirrors how FromYamlDocuments treats a stream as independent
+// documents rather than a single value.
+//
+// This is designed to be called from a template.
+func ToTomlArray(v []interface{}) string {
+	parts := make([]string, len(v))
+	for i, item := range v {
+		parts[i] = ToToml(item)
+	}
+	return strings.Join(parts, "")
+}
+
 // ToJson takes an interface, marshals it to json, and returns a string. It will
 // always return a string, even on marshal error (empty string).
 //
@@ -222,4 +567,105 @@ func FromJson(str string) map[string]interface{} {
 		m["Error"] = err.Error()
 	}
 	return m
+}
+
+// FromJsonArray converts a JSON document whose root is an array into a
+// []interface{}.
+//
+// This is not a general-purpose JSON parser, and will not parse all valid
+// JSON documents. Additionally, because its intended use is within templates
+// it tolerates errors. On error it returns a single-element slice whose
+// element is a map with the error message string in m["Error"], mirroring
+// how FromJson reports failures.
+func FromJsonArray(str string) []interface{} {
+	a := []interface{}{}
+
+	if err := json.Unmarshal([]byte(str), &a); err != nil {
+		return []interface{}{map[string]interface{}{"Error": err.Error()}}
+	}
+	return a
+}
+
+// Base returns the last element of path, mirroring path/filepath.Base.
+//
+// This is designed to be called from a template.
+func Base(p string) string {
+	return filepath.Base(p)
+}
+
+// Dirname returns all but the last element of path, mirroring
+// path/filepath.Dir.
+//
+// This is designed to be called from a template. It is named Dirname rather
+// than Dir to avoid colliding with the Dir type.
+func Dirname(p string) string {
+	return filepath.Dir(p)
+}
+
+// Ext returns the file name extension used by path, mirroring
+// path/filepath.Ext.
+//
+// This is designed to be called from a template.
+func Ext(p string) string {
+	return filepath.Ext(p)
+}
+
+// Clean returns the shortest path name equivalent to path, mirroring
+// path/filepath.Clean.
+//
+// This is designed to be called from a template.
+func Clean(p string) string {
+	return filepath.Clean(p)
+}
+
+// IsAbs reports whether the path is absolute, mirroring path/filepath.IsAbs.
+//
+// This is designed to be called from a template.
+func IsAbs(p string) bool {
+	return filepath.IsAbs(p)
+}
+
+// Join joins any number of path elements into a single path, mirroring
+// path/filepath.Join.
+//
+// This is designed to be called from a template.
+func Join(elem ...string) string {
+	return filepath.Join(elem...)
+}
+
+// ToSlash returns the path with its separators converted to forward
+// slashes, mirroring path/filepath.ToSlash. Templates that build keys to
+// match against Files.Glob patterns should run them through this first,
+// since glob patterns are always slash-separated.
+//
+// This is designed to be called from a template.
+func ToSlash(p string) string {
+	return filepath.ToSlash(p)
+}
+
+// Rel returns a relative path that is lexically equivalent to targpath when
+// joined to basepath, mirroring path/filepath.Rel. Unlike filepath.Rel it
+// swallows the error and returns targpath unchanged so it is safe to call
+// from a template.
+func Rel(basepath, targpath string) string {
+	r, err := filepath.Rel(basepath, targpath)
+	if err != nil {
+		return targpath
+	}
+	return r
+}
+
+// FuncMap returns the path helpers exposed to templates, keyed the same way
+// they are registered alongside ToYaml/ToJson and friends.
+func FuncMap() map[string]interface{} {
+	return map[string]interface{}{
+		"base":    Base,
+		"dirname": Dirname,
+		"ext":     Ext,
+		"clean":   Clean,
+		"isAbs":   IsAbs,
+		"join":    Join,
+		"toSlash": ToSlash,
+		"rel":     Rel,
+	}
 }
\ No newline at end of file
@@ -0,0 +1,71 @@
+package files
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFromYamlArrayParsesSequenceRoot(t *testing.T) {
+	got := FromYamlArray("- a\n- b\n- c\n")
+	want := []interface{}{"a", "b", "c"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("FromYamlArray() = %#v, want %#v", got, want)
+	}
+}
+
+func TestFromYamlArrayNormalizesNestedMapKeys(t *testing.T) {
+	got := FromYamlArray("- name: a\n  opts:\n    x: 1\n")
+	want := []interface{}{
+		map[string]interface{}{
+			"name": "a",
+			"opts": map[string]interface{}{"x": 1},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("FromYamlArray() = %#v, want %#v", got, want)
+	}
+}
+
+func TestFromJsonArrayParsesArrayRoot(t *testing.T) {
+	got := FromJsonArray(`["a", "b", "c"]`)
+	want := []interface{}{"a", "b", "c"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("FromJsonArray() = %#v, want %#v", got, want)
+	}
+}
+
+func TestFromYamlDocumentsSplitsOnSeparator(t *testing.T) {
+	got := FromYamlDocuments("name: a\n---\nname: b\n")
+	want := []map[string]interface{}{
+		{"name": "a"},
+		{"name": "b"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("FromYamlDocuments() = %#v, want %#v", got, want)
+	}
+}
+
+func TestToTomlArrayEncodesEachElementAsItsOwnDocument(t *testing.T) {
+	got := ToTomlArray([]interface{}{
+		map[string]interface{}{"name": "a"},
+		map[string]interface{}{"name": "b"},
+	})
+	want := "name = \"a\"\nname = \"b\"\n"
+	if got != want {
+		t.Fatalf("ToTomlArray() = %q, want %q", got, want)
+	}
+}
+
+func TestFromYamlDocumentsBadDocumentReportsErrorInPlace(t *testing.T) {
+	got := FromYamlDocuments("name: a\n---\n[1, 2\n")
+
+	if len(got) != 2 {
+		t.Fatalf("FromYamlDocuments() = %#v, want 2 documents", got)
+	}
+	if got[0]["name"] != "a" {
+		t.Fatalf("FromYamlDocuments()[0] = %#v, want name: a", got[0])
+	}
+	if _, ok := got[1]["Error"]; !ok {
+		t.Fatalf("FromYamlDocuments()[1] = %#v, want an Error key", got[1])
+	}
+}
@@ -0,0 +1,44 @@
+package files
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewFilesConvertsStringsToBytes(t *testing.T) {
+	f := NewFiles(map[string]string{"a.txt": "hello"})
+
+	if got := f.Get("a.txt"); got != "hello" {
+		t.Fatalf("Get(%q) = %q, want %q", "a.txt", got, "hello")
+	}
+	if got := f.GetBytes("a.txt"); !reflect.DeepEqual(got, []byte("hello")) {
+		t.Fatalf("GetBytes(%q) = %v, want %v", "a.txt", got, []byte("hello"))
+	}
+}
+
+func TestFilesGetBytesRoundTripsBinaryData(t *testing.T) {
+	binary := []byte{0x00, 0xff, 0x80, 0x01}
+	f := Files{"cert.der": binary}
+
+	if got := f.GetBytes("cert.der"); !reflect.DeepEqual(got, binary) {
+		t.Fatalf("GetBytes(%q) = %v, want %v", "cert.der", got, binary)
+	}
+}
+
+func TestFilesLinesSplitsOnNewline(t *testing.T) {
+	f := Files{"foo.txt": []byte("one\ntwo\nthree")}
+
+	got := f.Lines("foo.txt")
+	want := []string{"one", "two", "three"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Lines(%q) = %v, want %v", "foo.txt", got, want)
+	}
+}
+
+func TestFilesLinesMissingFileReturnsEmptySlice(t *testing.T) {
+	var f Files
+
+	if got := f.Lines("missing"); len(got) != 0 {
+		t.Fatalf("Lines(%q) on nil Files = %v, want empty", "missing", got)
+	}
+}
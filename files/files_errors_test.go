@@ -0,0 +1,111 @@
+package files
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestGetEReturnsErrorWithoutKillingProcess(t *testing.T) {
+	dir := Dir(os.TempDir())
+	dir.ClearErrors()
+
+	if _, err := dir.GetE("does-not-exist"); err == nil {
+		t.Fatal("GetE on a missing file returned no error")
+	}
+
+	if got := dir.Get("does-not-exist"); got != "" {
+		t.Fatalf("Get on a missing file = %q, want \"\"", got)
+	}
+
+	if errs := dir.Errors(); len(errs) == 0 {
+		t.Fatal("Errors() is empty after a failed Get/GetE")
+	}
+}
+
+func TestClearErrorsScopesToSubsequentCalls(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "tool-tmplt-errors")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	dir := Dir(tmp)
+	dir.ClearErrors()
+
+	if _, err := dir.GetE("missing"); err == nil {
+		t.Fatal("GetE on a missing file returned no error")
+	}
+	if len(dir.Errors()) == 0 {
+		t.Fatal("Errors() is empty after a failed GetE")
+	}
+
+	dir.ClearErrors()
+	if errs := dir.Errors(); len(errs) != 0 {
+		t.Fatalf("Errors() after ClearErrors = %v, want none", errs)
+	}
+}
+
+func TestRecordErrorCapsDistinctDirsTracked(t *testing.T) {
+	base := Dir(fmt.Sprintf("tool-tmplt-errors-bound-%d", len(dirErrorsOrder)))
+	for i := 0; i < maxTrackedDirs+10; i++ {
+		d := Dir(fmt.Sprintf("%s-%d", base, i))
+		d.ClearErrors()
+		d.recordError(fmt.Errorf("boom %d", i))
+	}
+
+	dirErrorsMu.Lock()
+	tracked := len(dirErrorsOrder)
+	dirErrorsMu.Unlock()
+
+	if tracked > maxTrackedDirs {
+		t.Fatalf("dirErrorsOrder tracked %d dirs, want at most %d", tracked, maxTrackedDirs)
+	}
+
+	first := Dir(fmt.Sprintf("%s-%d", base, 0))
+	if errs := first.Errors(); len(errs) != 0 {
+		t.Fatalf("Errors() for the evicted first Dir = %v, want none", errs)
+	}
+}
+
+func TestRecordErrorCapsErrorsPerDir(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "tool-tmplt-errors")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	dir := Dir(tmp)
+	dir.ClearErrors()
+
+	for i := 0; i < maxErrorsPerDir+10; i++ {
+		dir.recordError(fmt.Errorf("boom %d", i))
+	}
+
+	errs := dir.Errors()
+	if len(errs) != maxErrorsPerDir {
+		t.Fatalf("Errors() returned %d entries, want %d", len(errs), maxErrorsPerDir)
+	}
+	if errs[len(errs)-1].Error() != fmt.Sprintf("boom %d", maxErrorsPerDir+9) {
+		t.Fatalf("Errors() dropped the newest entries instead of the oldest: last = %v", errs[len(errs)-1])
+	}
+}
+
+func TestGlobEReturnsMatches(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "tool-tmplt-errors")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	writeFile(t, tmp, "a.yaml", "a: 1\n")
+
+	f, err := Dir(tmp).GlobE("*.yaml")
+	if err != nil {
+		t.Fatalf("GlobE: %v", err)
+	}
+	if got := f.Get("a.yaml"); got != "a: 1\n" {
+		t.Fatalf("f.Get(%q) = %q, want %q", "a.yaml", got, "a: 1\n")
+	}
+}
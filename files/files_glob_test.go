@@ -0,0 +1,52 @@
+package files
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDirGlobSkipsSubdirectoriesButKeepsMatchingFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tool-tmplt-glob")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.Mkdir(filepath.Join(dir, "config"), 0755); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "config", "sub"), 0755); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+	writeFile(t, filepath.Join(dir, "config"), "a.yaml", "a: 1\n")
+	writeFile(t, filepath.Join(dir, "config", "sub"), "b.yaml", "b: 2\n")
+
+	f, err := Dir(dir).GlobE("config/**")
+	if err != nil {
+		t.Fatalf("GlobE: %v", err)
+	}
+
+	if got := f.Get("config/a.yaml"); got != "a: 1\n" {
+		t.Fatalf("f.Get(%q) = %q, want %q", "config/a.yaml", got, "a: 1\n")
+	}
+	if _, ok := f["config/sub"]; ok {
+		t.Fatalf("Files contains the subdirectory entry %q, want it skipped", "config/sub")
+	}
+}
+
+func TestFilesGlobChainsAgainstBaseName(t *testing.T) {
+	f := Files{
+		"config/a.yaml": []byte("a"),
+		"config/b.txt":  []byte("b"),
+	}
+
+	got := f.Glob("*.yaml")
+	if len(got) != 1 {
+		t.Fatalf("Glob(%q) = %v, want exactly config/a.yaml", "*.yaml", got)
+	}
+	if _, ok := got["config/a.yaml"]; !ok {
+		t.Fatalf("Glob(%q) missing config/a.yaml, got %v", "*.yaml", got)
+	}
+}
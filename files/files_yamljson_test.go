@@ -0,0 +1,35 @@
+package files
+
+import "testing"
+
+func TestYAMLToJsonNormalizesNestedMapKeys(t *testing.T) {
+	got := YAMLToJson("name: a\nopts:\n  x: 1\n")
+	want := `{"name":"a","opts":{"x":1}}`
+	if got != want {
+		t.Fatalf("YAMLToJson() = %q, want %q", got, want)
+	}
+}
+
+func TestJSONToYamlRoundTrips(t *testing.T) {
+	got := JSONToYaml(`{"name":"a","opts":{"x":1}}`)
+	want := "name: a\nopts:\n  x: 1\n"
+	if got != want {
+		t.Fatalf("JSONToYaml() = %q, want %q", got, want)
+	}
+}
+
+func TestFromYamlThenToJsonLosslessRoundTrip(t *testing.T) {
+	m := FromYaml("name: a\nopts:\n  x: 1\n")
+	got := ToJson(m)
+	want := `{"name":"a","opts":{"x":1}}`
+	if got != want {
+		t.Fatalf("ToJson(FromYaml(...)) = %q, want %q", got, want)
+	}
+}
+
+func TestNormalizeYamlKeysErrorsOnNonStringKey(t *testing.T) {
+	_, err := normalizeYamlKeys(map[interface{}]interface{}{1: "a"})
+	if err == nil {
+		t.Fatal("normalizeYamlKeys with a non-string key returned no error")
+	}
+}